@@ -0,0 +1,318 @@
+package onedrive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token はトークンエンドポイントから取得したアクセストークンを表す。
+type Token struct {
+	AccessToken string
+	ExpiresIn   int // 秒単位の有効期間
+}
+
+// TokenSource はアクセストークンを発行する認証バックエンドが実装するインターフェース。
+// client-credentials、authorization-code (+refresh token)、device-code の
+// いずれのフローも同じインターフェースで Client に渡せる。
+type TokenSource interface {
+	Token(ctx context.Context) (Token, error)
+}
+
+func postForm(ctx context.Context, httpClient *http.Client, tokenURL string, form url.Values) (Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to send token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResponse struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Error        string `json:"error"`
+		ErrorDesc    string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return Token{}, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if tokenResponse.Error != "" {
+			return Token{}, fmt.Errorf("token request failed: %s (%s)", tokenResponse.Error, tokenResponse.ErrorDesc)
+		}
+		return Token{}, fmt.Errorf("token request failed: %s", resp.Status)
+	}
+
+	return Token{AccessToken: tokenResponse.AccessToken, ExpiresIn: tokenResponse.ExpiresIn}, nil
+}
+
+// ClientCredentials はアプリ専用権限 (client_credentials グラント) による認証を行う。
+// SharePoint のサイトなど、アプリとして振る舞う場合に使う。
+type ClientCredentials struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	Scope        string // 未指定時は "{GraphURL}/.default"
+
+	Endpoints  Endpoints
+	HTTPClient *http.Client
+}
+
+// Token は client_credentials グラントでアクセストークンを取得する。
+func (c *ClientCredentials) Token(ctx context.Context) (Token, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	scope := c.Scope
+	if scope == "" {
+		scope = c.Endpoints.GraphURL + "/.default"
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"scope":         {scope},
+	}
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", c.Endpoints.AuthURL, c.TenantID)
+	return postForm(ctx, httpClient, tokenURL, form)
+}
+
+// setEndpoints は NewSharePointClient/NewUserDriveClient が解決した Region の
+// Endpoints を反映する。Client と認証先のクラウドがずれないようにするための
+// もので、呼び出し元が Endpoints を直接設定する必要はない。
+func (c *ClientCredentials) setEndpoints(ep Endpoints) {
+	c.Endpoints = ep
+}
+
+// AuthorizationCode は委任されたユーザー権限による認証を行う。個人用 OneDrive
+// (/me/drive) へのアクセスなど、ユーザーの同意を必要とする場合に使う。
+// 初回の認可コード交換と、以降のリフレッシュトークンによる更新の両方に対応する。
+type AuthorizationCode struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	Scope        string
+
+	// Code は初回交換に使う認可コード。RefreshToken が設定済みの場合は不要。
+	Code string
+	// RefreshToken が設定されていればリフレッシュを優先する。
+	RefreshToken string
+
+	Endpoints  Endpoints
+	HTTPClient *http.Client
+
+	mu          sync.Mutex
+	lastRefresh string
+}
+
+// Token は認可コードまたはリフレッシュトークンを使ってアクセストークンを取得する。
+// Graph がローテーションした新しいリフレッシュトークンは以後の呼び出しに引き継がれる。
+func (a *AuthorizationCode) Token(ctx context.Context) (Token, error) {
+	httpClient := a.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	a.mu.Lock()
+	refreshToken := a.RefreshToken
+	if refreshToken == "" {
+		refreshToken = a.lastRefresh
+	}
+	a.mu.Unlock()
+
+	form := url.Values{
+		"client_id": {a.ClientID},
+		"scope":     {a.Scope},
+	}
+	if a.ClientSecret != "" {
+		form.Set("client_secret", a.ClientSecret)
+	}
+	if refreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", refreshToken)
+	} else {
+		form.Set("grant_type", "authorization_code")
+		form.Set("code", a.Code)
+		form.Set("redirect_uri", a.RedirectURI)
+	}
+
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", a.Endpoints.AuthURL, a.TenantID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to send token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResponse struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Error        string `json:"error"`
+		ErrorDesc    string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return Token{}, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Token{}, fmt.Errorf("token request failed: %s (%s)", tokenResponse.Error, tokenResponse.ErrorDesc)
+	}
+
+	if tokenResponse.RefreshToken != "" {
+		a.mu.Lock()
+		a.lastRefresh = tokenResponse.RefreshToken
+		a.mu.Unlock()
+	}
+
+	return Token{AccessToken: tokenResponse.AccessToken, ExpiresIn: tokenResponse.ExpiresIn}, nil
+}
+
+// setEndpoints は NewSharePointClient/NewUserDriveClient が解決した Region の
+// Endpoints を反映する。
+func (a *AuthorizationCode) setEndpoints(ep Endpoints) {
+	a.Endpoints = ep
+}
+
+// DeviceCode はブラウザを起動できないヘッドレス CLI 向けの device-code フローを行う。
+// Start でデバイスコードを発行し、ユーザーがブラウザで承認するのを Token がポーリングして待つ。
+type DeviceCode struct {
+	TenantID string
+	ClientID string
+	Scope    string
+
+	Endpoints  Endpoints
+	HTTPClient *http.Client
+
+	deviceCode string
+	interval   time.Duration
+}
+
+// setEndpoints は NewSharePointClient/NewUserDriveClient が解決した Region の
+// Endpoints を反映する。
+func (d *DeviceCode) setEndpoints(ep Endpoints) {
+	d.Endpoints = ep
+}
+
+// DeviceCodePrompt はユーザーに提示する認証用の情報を表す。
+type DeviceCodePrompt struct {
+	UserCode        string
+	VerificationURI string
+	Message         string
+	ExpiresIn       int
+}
+
+// Start はデバイスコードを発行し、ユーザーに提示すべき情報を返す。
+// 戻り値を表示した後に Token を呼び出してポーリングを開始する。
+func (d *DeviceCode) Start(ctx context.Context) (DeviceCodePrompt, error) {
+	httpClient := d.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	form := url.Values{
+		"client_id": {d.ClientID},
+		"scope":     {d.Scope},
+	}
+	deviceURL := fmt.Sprintf("%s/%s/oauth2/v2.0/devicecode", d.Endpoints.AuthURL, d.TenantID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, deviceURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return DeviceCodePrompt{}, fmt.Errorf("failed to create device code request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return DeviceCodePrompt{}, fmt.Errorf("failed to send device code request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var deviceResponse struct {
+		DeviceCode      string `json:"device_code"`
+		UserCode        string `json:"user_code"`
+		VerificationURI string `json:"verification_uri"`
+		ExpiresIn       int    `json:"expires_in"`
+		Interval        int    `json:"interval"`
+		Message         string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&deviceResponse); err != nil {
+		return DeviceCodePrompt{}, fmt.Errorf("failed to decode device code response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return DeviceCodePrompt{}, fmt.Errorf("failed to get device code: %s", resp.Status)
+	}
+
+	d.deviceCode = deviceResponse.DeviceCode
+	d.interval = time.Duration(deviceResponse.Interval) * time.Second
+	if d.interval == 0 {
+		d.interval = 5 * time.Second
+	}
+
+	return DeviceCodePrompt{
+		UserCode:        deviceResponse.UserCode,
+		VerificationURI: deviceResponse.VerificationURI,
+		Message:         deviceResponse.Message,
+		ExpiresIn:       deviceResponse.ExpiresIn,
+	}, nil
+}
+
+// Token はユーザーが認証を完了するまでトークンエンドポイントをポーリングする。
+// Start を呼んだ後にのみ使用できる。
+func (d *DeviceCode) Token(ctx context.Context) (Token, error) {
+	if d.deviceCode == "" {
+		return Token{}, fmt.Errorf("device code flow not started: call Start first")
+	}
+	httpClient := d.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	form := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"client_id":   {d.ClientID},
+		"device_code": {d.deviceCode},
+	}
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", d.Endpoints.AuthURL, d.TenantID)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return Token{}, ctx.Err()
+		case <-ticker.C:
+		}
+
+		tok, err := postForm(ctx, httpClient, tokenURL, form)
+		if err == nil {
+			return tok, nil
+		}
+		if strings.Contains(err.Error(), "authorization_pending") {
+			continue
+		}
+		if strings.Contains(err.Error(), "slow_down") {
+			ticker.Reset(d.interval + time.Second)
+			continue
+		}
+		return Token{}, err
+	}
+}
@@ -0,0 +1,44 @@
+package onedrive
+
+import "time"
+
+// BackoffPolicy はチャンクアップロード失敗時の再試行間隔を決める。
+type BackoffPolicy interface {
+	// Next は attempt 回目(1 始まり)の再試行前に待つ時間を返す。
+	Next(attempt int) time.Duration
+}
+
+// ConstantBackoff は常に一定の間隔で再試行する。
+type ConstantBackoff struct {
+	Interval time.Duration
+}
+
+// Next は常に Interval を返す。
+func (b ConstantBackoff) Next(attempt int) time.Duration {
+	return b.Interval
+}
+
+// ExponentialBackoff は試行ごとに待ち時間を倍にしていき、Max で頭打ちにする。
+type ExponentialBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Next は Base * 2^(attempt-1) を Max で切り詰めて返す。
+func (b ExponentialBackoff) Next(attempt int) time.Duration {
+	d := b.Base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= b.Max {
+			return b.Max
+		}
+	}
+	if d > b.Max {
+		return b.Max
+	}
+	return d
+}
+
+// defaultBackoff は Cloudreve の chunk/backoff パッケージに倣い、チャンクの
+// PUT が失敗するたびに約 5 秒待ってから再試行する。
+var defaultBackoff BackoffPolicy = ConstantBackoff{Interval: 5 * time.Second}
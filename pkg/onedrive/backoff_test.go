@@ -0,0 +1,56 @@
+package onedrive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConstantBackoffIsAlwaysTheSameInterval(t *testing.T) {
+	b := ConstantBackoff{Interval: 5 * time.Second}
+	for attempt := 1; attempt <= 5; attempt++ {
+		if got := b.Next(attempt); got != 5*time.Second {
+			t.Errorf("Next(%d) = %v, want 5s", attempt, got)
+		}
+	}
+}
+
+func TestExponentialBackoffDoublesEachAttempt(t *testing.T) {
+	b := ExponentialBackoff{Base: time.Second, Max: time.Hour}
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+	}
+	for _, c := range cases {
+		if got := b.Next(c.attempt); got != c.want {
+			t.Errorf("Next(%d) = %v, want %v", c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestExponentialBackoffCapsAtMax(t *testing.T) {
+	b := ExponentialBackoff{Base: time.Second, Max: 10 * time.Second}
+	if got := b.Next(10); got != 10*time.Second {
+		t.Errorf("Next(10) = %v, want capped at 10s", got)
+	}
+}
+
+func TestExponentialBackoffBaseAboveMax(t *testing.T) {
+	b := ExponentialBackoff{Base: time.Minute, Max: 10 * time.Second}
+	if got := b.Next(1); got != 10*time.Second {
+		t.Errorf("Next(1) with Base > Max = %v, want capped at Max", got)
+	}
+}
+
+func TestDefaultBackoffIsFiveSecondConstant(t *testing.T) {
+	if got := defaultBackoff.Next(1); got != 5*time.Second {
+		t.Errorf("defaultBackoff.Next(1) = %v, want 5s", got)
+	}
+	if got := defaultBackoff.Next(7); got != 5*time.Second {
+		t.Errorf("defaultBackoff.Next(7) = %v, want 5s", got)
+	}
+}
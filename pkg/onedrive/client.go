@@ -0,0 +1,269 @@
+// Package onedrive は Microsoft Graph 経由で OneDrive / SharePoint のドライブを
+// 操作するためのクライアントを提供する。client-credentials、authorization-code
+// (+refresh token)、device-code の認証フローと、グローバル・中国・米国政府・
+// ドイツの各クラウド環境をサポートする。
+package onedrive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin はアクセストークンの有効期限が切れる何秒前に再取得するか。
+const tokenRefreshMargin = 5 * time.Minute
+
+// Client は Microsoft Graph の特定のドライブ (SharePoint サイトまたは個人用
+// OneDrive) に対する操作をまとめたクライアント。ゼロ値では使えないため
+// NewSharePointClient か NewUserDriveClient で生成する。
+type Client struct {
+	httpClient *http.Client
+	endpoints  Endpoints
+	auth       TokenSource
+
+	// driveBaseURL は "/sites/{id}/drive" または "/me/drive" のように、
+	// テナント種別ごとに異なる Graph のベースパスを保持する。
+	driveBaseURL string
+
+	// metrics が設定されていれば、Uploader/ProxyUploader が送信したバイト数を
+	// onedrive_bytes_uploaded_total に記録する。
+	metrics *Metrics
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// Option は Client の生成時に任意の設定を適用する。
+type Option func(*Client)
+
+// endpointSetter は Region 解決済みの Endpoints を受け取れる TokenSource が
+// 実装するインターフェース。ClientCredentials/AuthorizationCode/DeviceCode は
+// いずれもこれを満たすため、newClient が Region を Client と TokenSource の
+// 両方に同じ値で伝える一本化された入り口になる。呼び出し元が TokenSource の
+// Endpoints を別途設定する必要はない (しても Client 生成時に上書きされる)。
+type endpointSetter interface {
+	setEndpoints(Endpoints)
+}
+
+// WithRegion は接続先の Microsoft クラウド環境を切り替える。未指定時は
+// RegionGlobal が使われる。
+func WithRegion(region Region) Option {
+	return func(c *Client) {
+		c.endpoints = endpointsFor(region)
+	}
+}
+
+// WithEndpoints は 4 つの定義済み Region 以外のエンドポイント (社内プロキシや
+// テスト用のスタブサーバーなど) を直接指定する。WithRegion と同様、指定した
+// Endpoints は auth に渡した TokenSource が endpointSetter を実装していれば
+// そちらにも伝わる。
+func WithEndpoints(endpoints Endpoints) Option {
+	return func(c *Client) {
+		c.endpoints = endpoints
+	}
+}
+
+// WithHTTPClient は内部で使う *http.Client を差し替える。
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUploadMetrics はアップロード済みバイト数の記録先を設定する。
+func WithUploadMetrics(m *Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// recordBytesUploaded は metrics が設定されていれば onedrive_bytes_uploaded_total
+// に n バイトを加算する。
+func (c *Client) recordBytesUploaded(n int64) {
+	if c.metrics != nil {
+		c.metrics.BytesUploaded.Add(float64(n))
+	}
+}
+
+func newClient(auth TokenSource, opts ...Option) *Client {
+	c := &Client{
+		httpClient: http.DefaultClient,
+		endpoints:  endpointsFor(RegionGlobal),
+		auth:       auth,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if setter, ok := auth.(endpointSetter); ok {
+		setter.setEndpoints(c.endpoints)
+	}
+	return c
+}
+
+// NewSharePointClient は SharePoint サイト上のドキュメントライブラリを操作する
+// Client を生成する。siteID は GetSiteID で解決済みの Graph サイト ID
+// (例: "contoso.sharepoint.com,GUID,GUID") を渡す。WithRegion/WithEndpoints で
+// 指定した Endpoints は、auth が endpointSetter を実装していれば (標準で提供
+// している ClientCredentials/AuthorizationCode/DeviceCode はいずれも実装する)
+// そちらにも伝わるため、Client と認証先のクラウドがずれる心配はない。
+func NewSharePointClient(siteID string, auth TokenSource, opts ...Option) *Client {
+	c := newClient(auth, opts...)
+	c.driveBaseURL = fmt.Sprintf("/sites/%s/drive", siteID)
+	return c
+}
+
+// NewUserDriveClient は認証されたユーザー本人の個人用 OneDrive (/me/drive) を
+// 操作する Client を生成する。委任認証 (AuthorizationCode か DeviceCode) と
+// 組み合わせて使う。
+func NewUserDriveClient(auth TokenSource, opts ...Option) *Client {
+	c := newClient(auth, opts...)
+	c.driveBaseURL = "/me/drive"
+	return c
+}
+
+// GetSiteID はホスト名とサイトパスから Graph のサイト ID を解決する。
+// SharePoint サイトに対して NewSharePointClient を組み立てる前に呼び出す。
+func GetSiteID(ctx context.Context, auth TokenSource, httpClient *http.Client, endpoints Endpoints, hostname, sitePath string) (string, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	token, err := auth.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/v1.0/sites/%s:/%s", endpoints.GraphURL, hostname, sitePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get site ID: %s", resp.Status)
+	}
+
+	var siteInfo struct {
+		ID string `json:"id"`
+	}
+	if err := decodeJSON(resp.Body, &siteInfo); err != nil {
+		return "", fmt.Errorf("failed to decode site info: %w", err)
+	}
+
+	return siteInfo.ID, nil
+}
+
+// accessTokenLocked は有効なアクセストークンを返す。期限切れ間近であれば
+// auth.Token を使って再取得する。mu のロックを取得した状態で呼ぶこと。
+func (c *Client) ensureToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && time.Until(c.expiresAt) > tokenRefreshMargin {
+		return c.accessToken, nil
+	}
+
+	tok, err := c.auth.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh access token: %w", err)
+	}
+
+	c.accessToken = tok.AccessToken
+	c.expiresAt = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
+
+// newRequest は Graph API 向けのリクエストを組み立て、認証ヘッダーを付与する。
+// path は driveBaseURL からの相対パス ("/root:/a/b:/content" など) か、
+// "http(s)://" で始まる絶対 URL (nextLink やアップロードセッション URL) を渡せる。
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	token, err := c.ensureToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reqURL := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		reqURL = c.endpoints.GraphURL + "/v1.0" + c.driveBaseURL + path
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req, nil
+}
+
+func decodeJSON(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// HTTPClient は内部で使っている *http.Client を返す。driveBaseURL の外側に
+// あるリソース (/subscriptions など) を扱うサブパッケージが NewGraphRequest
+// と組み合わせて使う。
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// NewGraphRequest は driveBaseURL を経由しない任意の Graph リソースへの
+// リクエストを組み立てる。path は "/subscriptions" のように v1.0 からの
+// 絶対パスを渡す。
+func (c *Client) NewGraphRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	token, err := c.ensureToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reqURL := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		reqURL = c.endpoints.GraphURL + "/v1.0" + path
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req, nil
+}
+
+// createUploadSession はアップロードセッションを作成し、チャンク PUT 先の
+// uploadUrl を返す。Uploader と ProxyUploader の両方から使われる。
+func (c *Client) createUploadSession(ctx context.Context, destPath string) (string, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, fmt.Sprintf("/root:/%s:/createUploadSession", destPath), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload session request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send upload session request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to create upload session: %s", resp.Status)
+	}
+
+	var sessionResponse struct {
+		UploadURL string `json:"uploadUrl"`
+	}
+	if err := decodeJSON(resp.Body, &sessionResponse); err != nil {
+		return "", fmt.Errorf("failed to decode upload session response: %w", err)
+	}
+	return sessionResponse.UploadURL, nil
+}
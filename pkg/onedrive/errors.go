@@ -0,0 +1,57 @@
+package onedrive
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// GraphError は Microsoft Graph が返すエラーレスポンスの error.code / error.message
+// を保持する。errors.Is(err, onedrive.ErrItemNotFound) のように Code だけで
+// 比較できるようにしている。
+type GraphError struct {
+	Code       string
+	Message    string
+	StatusCode int
+}
+
+func (e *GraphError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("graph error: %s (status %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("graph error: %s: %s (status %d)", e.Code, e.Message, e.StatusCode)
+}
+
+// Is は error.code が一致するかどうかだけで比較する。errors.Is が
+// sentinel (ErrItemNotFound など) との比較に使う。
+func (e *GraphError) Is(target error) bool {
+	t, ok := target.(*GraphError)
+	if !ok {
+		return false
+	}
+	return e.Code == t.Code
+}
+
+// 呼び出し元が errors.Is で分岐できるように用意した代表的な Graph エラーコード。
+// StatusCode や Message は比較に使われないため空のままでよい。
+var (
+	ErrItemNotFound      = &GraphError{Code: "itemNotFound"}
+	ErrNameAlreadyExists = &GraphError{Code: "nameAlreadyExists"}
+	ErrQuotaLimitReached = &GraphError{Code: "quotaLimitReached"}
+	ErrAccessDenied      = &GraphError{Code: "accessDenied"}
+)
+
+// parseGraphError はエラーレスポンスのボディから GraphError を組み立てる。
+// ボディが Graph のエラー形式でない場合は素の HTTP ステータスを使う。
+func parseGraphError(resp *http.Response) error {
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error.Code == "" {
+		return &GraphError{Code: "unknown", Message: resp.Status, StatusCode: resp.StatusCode}
+	}
+	return &GraphError{Code: body.Error.Code, Message: body.Error.Message, StatusCode: resp.StatusCode}
+}
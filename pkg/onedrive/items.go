@@ -0,0 +1,453 @@
+package onedrive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// itemURL はパスまたは "id:" プレフィックス付きの ID から、ドライブアイテムの
+// メタデータ取得に使う相対パスを組み立てる。
+func itemURL(pathOrID string) string {
+	if id, ok := strings.CutPrefix(pathOrID, "id:"); ok {
+		return "/items/" + id
+	}
+	return "/root:/" + pathOrID
+}
+
+// itemActionURL はパスまたは ID アイテムに対するアクション (children, content,
+// copy, createLink など) の相対パスを組み立てる。
+func itemActionURL(pathOrID, action string) string {
+	if id, ok := strings.CutPrefix(pathOrID, "id:"); ok {
+		return "/items/" + id + "/" + action
+	}
+	return "/root:/" + pathOrID + ":/" + action
+}
+
+// ListOptions は ListChildren の挙動を調整する。
+type ListOptions struct {
+	// PageSize は 1 ページあたりの件数のヒント ($top)。0 なら Graph のデフォルト。
+	PageSize int
+}
+
+// ListChildren はフォルダー直下のアイテム一覧を返す。@odata.nextLink が
+// ある限り自動でページングして結合する。path を空文字にするとドライブの
+// ルート直下を返す。
+func (c *Client) ListChildren(ctx context.Context, path string, opts ListOptions) ([]DriveItem, error) {
+	url := itemActionURL(path, "children")
+	if path == "" {
+		url = "/root/children"
+	}
+	if opts.PageSize > 0 {
+		url += "?$top=" + strconv.Itoa(opts.PageSize)
+	}
+
+	var items []DriveItem
+	for url != "" {
+		req, err := c.newRequest(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list children: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := parseGraphError(resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var page struct {
+			Value    []DriveItem `json:"value"`
+			NextLink string      `json:"@odata.nextLink"`
+		}
+		if err := decodeJSON(resp.Body, &page); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to decode children page: %w", err)
+		}
+		resp.Body.Close()
+
+		items = append(items, page.Value...)
+		url = page.NextLink
+	}
+
+	return items, nil
+}
+
+// GetItemOptions は GetItem が返すメタデータの範囲を調整する。
+type GetItemOptions struct {
+	// ExpandThumbnails を true にすると ?expand=thumbnails を付与し、
+	// レスポンスにサムネイル URL を含める。
+	ExpandThumbnails bool
+}
+
+// GetItem はアイテムのメタデータを取得する。path は "id:" プレフィックスを
+// 付けると ID 指定になる。file.hashes (sha1/sha256/quickXorHash) は
+// ファイルであれば自動的に含まれる。
+func (c *Client) GetItem(ctx context.Context, pathOrID string, opts GetItemOptions) (*DriveItem, error) {
+	url := itemURL(pathOrID)
+	if opts.ExpandThumbnails {
+		url += "?expand=thumbnails"
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, parseGraphError(resp)
+	}
+
+	var item DriveItem
+	if err := decodeJSON(resp.Body, &item); err != nil {
+		return nil, fmt.Errorf("failed to decode drive item: %w", err)
+	}
+	return &item, nil
+}
+
+// Download はアイテムの内容を w に書き込む。rangeStart/rangeEnd が両方 0 の
+// 場合は全体を取得し、そうでなければ HTTP Range リクエストで部分取得する。
+// Graph は /content に対して実体データの置き場所へ 302 リダイレクトするが、
+// net/http はホストをまたぐリダイレクトで Authorization ヘッダーを自動的に
+// 落とすため、そのまま辿らせて問題ない。
+func (c *Client) Download(ctx context.Context, pathOrID string, w io.Writer, rangeStart, rangeEnd int64) error {
+	req, err := c.newRequest(ctx, http.MethodGet, itemActionURL(pathOrID, "content"), nil)
+	if err != nil {
+		return err
+	}
+	if rangeStart != 0 || rangeEnd != 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", rangeStart, rangeEnd))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download item: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return parseGraphError(resp)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to write downloaded content: %w", err)
+	}
+	return nil
+}
+
+// Delete はアイテムを削除する。
+func (c *Client) Delete(ctx context.Context, pathOrID string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, itemURL(pathOrID), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete item: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return parseGraphError(resp)
+	}
+	return nil
+}
+
+// Move はアイテムを別フォルダーに移動し、必要ならリネームする。
+// newParentID は移動先フォルダーのアイテム ID。newName が空なら名前は
+// 変更しない。
+func (c *Client) Move(ctx context.Context, pathOrID, newParentID, newName string) (*DriveItem, error) {
+	body := map[string]interface{}{
+		"parentReference": map[string]string{"id": newParentID},
+	}
+	if newName != "" {
+		body["name"] = newName
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode move request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPatch, itemURL(pathOrID), strings.NewReader(string(payload)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move item: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, parseGraphError(resp)
+	}
+
+	var item DriveItem
+	if err := decodeJSON(resp.Body, &item); err != nil {
+		return nil, fmt.Errorf("failed to decode drive item: %w", err)
+	}
+	return &item, nil
+}
+
+// Copy はアイテムを別フォルダーにコピーする。Graph のコピーは非同期のため、
+// 202 Accepted で返る Location (モニター URL) を 303 See Other になるまで
+// ポーリングしてから、完了後のアイテムを取得して返す。
+func (c *Client) Copy(ctx context.Context, pathOrID, newParentID, newName string) (*DriveItem, error) {
+	body := map[string]interface{}{
+		"parentReference": map[string]string{"id": newParentID},
+	}
+	if newName != "" {
+		body["name"] = newName
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode copy request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, itemActionURL(pathOrID, "copy"), strings.NewReader(string(payload)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start copy: %w", err)
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		defer resp.Body.Close()
+		return nil, parseGraphError(resp)
+	}
+	monitorURL := resp.Header.Get("Location")
+	resp.Body.Close()
+
+	return c.pollCopyMonitor(ctx, monitorURL)
+}
+
+func (c *Client) pollCopyMonitor(ctx context.Context, monitorURL string) (*DriveItem, error) {
+	const pollInterval = 2 * time.Second
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, monitorURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create copy monitor request: %w", err)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to poll copy monitor: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusSeeOther {
+			itemURL := resp.Header.Get("Location")
+			resp.Body.Close()
+			return c.fetchAbsoluteItem(ctx, itemURL)
+		}
+
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			return nil, fmt.Errorf("copy monitor returned unexpected status: %s", resp.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (c *Client) fetchAbsoluteItem(ctx context.Context, absoluteURL string) (*DriveItem, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, absoluteURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch copied item: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, parseGraphError(resp)
+	}
+
+	var item DriveItem
+	if err := decodeJSON(resp.Body, &item); err != nil {
+		return nil, fmt.Errorf("failed to decode drive item: %w", err)
+	}
+	return &item, nil
+}
+
+// Delta はドライブルート以下の変更分だけを取得する。token には前回呼び出しで
+// 得た delta トークンを渡す (初回は空文字でよい)。戻り値の nextToken を永続化
+// しておき、次回の呼び出しに渡すことで増分取得を続けられる。
+func (c *Client) Delta(ctx context.Context, token string) ([]DriveItem, string, error) {
+	url := "/root/delta"
+	if token != "" {
+		url += "?token=" + token
+	}
+
+	var items []DriveItem
+	nextToken := token
+	for url != "" {
+		req, err := c.newRequest(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, "", err
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to query delta: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := parseGraphError(resp)
+			resp.Body.Close()
+			return nil, "", err
+		}
+
+		var page struct {
+			Value     []DriveItem `json:"value"`
+			NextLink  string      `json:"@odata.nextLink"`
+			DeltaLink string      `json:"@odata.deltaLink"`
+		}
+		if err := decodeJSON(resp.Body, &page); err != nil {
+			resp.Body.Close()
+			return nil, "", fmt.Errorf("failed to decode delta page: %w", err)
+		}
+		resp.Body.Close()
+
+		items = append(items, page.Value...)
+		if page.DeltaLink != "" {
+			if t := deltaTokenFromLink(page.DeltaLink); t != "" {
+				nextToken = t
+			}
+		}
+		url = page.NextLink
+	}
+
+	return items, nextToken, nil
+}
+
+// deltaTokenFromLink は @odata.deltaLink の token クエリパラメーターを取り出す。
+func deltaTokenFromLink(link string) string {
+	idx := strings.Index(link, "token=")
+	if idx == -1 {
+		return ""
+	}
+	token := link[idx+len("token="):]
+	if amp := strings.Index(token, "&"); amp != -1 {
+		token = token[:amp]
+	}
+	return token
+}
+
+// CreateFolder はフォルダーを作成する。同名のフォルダーが既にあれば Graph が
+// 自動でリネームする (conflictBehavior: rename)。
+func (c *Client) CreateFolder(ctx context.Context, parentPath, name string) (*DriveItem, error) {
+	body := map[string]interface{}{
+		"name":                              name,
+		"folder":                            map[string]interface{}{},
+		"@microsoft.graph.conflictBehavior": "rename",
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode create folder request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, itemActionURL(parentPath, "children"), strings.NewReader(string(payload)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, parseGraphError(resp)
+	}
+
+	var item DriveItem
+	if err := decodeJSON(resp.Body, &item); err != nil {
+		return nil, fmt.Errorf("failed to decode drive item: %w", err)
+	}
+	return &item, nil
+}
+
+// ShareLinkScope は CreateShareLink で誰がリンクを使えるかを指定する。
+type ShareLinkScope string
+
+const (
+	ShareLinkScopeAnonymous    ShareLinkScope = "anonymous"
+	ShareLinkScopeOrganization ShareLinkScope = "organization"
+)
+
+// ShareLinkType は CreateShareLink で発行するリンクの種類を指定する。
+type ShareLinkType string
+
+const (
+	ShareLinkView ShareLinkType = "view"
+	ShareLinkEdit ShareLinkType = "edit"
+)
+
+// CreateShareLink は閲覧・編集用の共有リンクを発行し、その URL を返す。
+func (c *Client) CreateShareLink(ctx context.Context, pathOrID string, scope ShareLinkScope, linkType ShareLinkType) (string, error) {
+	body := map[string]interface{}{
+		"type":  linkType,
+		"scope": scope,
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode create link request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, itemActionURL(pathOrID, "createLink"), strings.NewReader(string(payload)))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create share link: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", parseGraphError(resp)
+	}
+
+	var linkResponse struct {
+		Link struct {
+			WebURL string `json:"webUrl"`
+		} `json:"link"`
+	}
+	if err := decodeJSON(resp.Body, &linkResponse); err != nil {
+		return "", fmt.Errorf("failed to decode share link response: %w", err)
+	}
+	return linkResponse.Link.WebURL, nil
+}
@@ -0,0 +1,59 @@
+package onedrive
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics は Transport が記録する Prometheus の指標をまとめたもの。
+type Metrics struct {
+	RequestsTotal   *prometheus.CounterVec
+	RequestDuration *prometheus.HistogramVec
+	BytesUploaded   prometheus.Counter
+}
+
+// NewMetrics は reg に登録された Metrics を生成する。reg に nil を渡すと
+// デフォルトレジストリに登録する。
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	factory := promauto.With(reg)
+	return &Metrics{
+		RequestsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "onedrive_requests_total",
+			Help: "Total number of Microsoft Graph requests made by the onedrive client.",
+		}, []string{"op", "status"}),
+		RequestDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "onedrive_request_duration_seconds",
+			Help:    "Duration of Microsoft Graph requests made by the onedrive client.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"op"}),
+		BytesUploaded: factory.NewCounter(prometheus.CounterOpts{
+			Name: "onedrive_bytes_uploaded_total",
+			Help: "Total number of bytes uploaded through the onedrive client.",
+		}),
+	}
+}
+
+// classifyOp は URL パスから大まかな操作名を推定する。ラベルのカーディナリ
+// ティを抑えるため、アイテムのパスや ID は含めない。
+func classifyOp(method, path string) string {
+	switch {
+	case strings.Contains(path, "createUploadSession"):
+		return "createUploadSession"
+	case strings.Contains(path, ":/content") || strings.HasSuffix(path, "/content"):
+		return "content"
+	case strings.Contains(path, ":/copy"):
+		return "copy"
+	case strings.Contains(path, ":/createLink"):
+		return "createLink"
+	case strings.HasSuffix(path, "/children"):
+		return "children"
+	case strings.Contains(path, "/delta"):
+		return "delta"
+	case strings.HasPrefix(path, "/subscriptions"):
+		return "subscriptions"
+	default:
+		return strings.ToLower(method)
+	}
+}
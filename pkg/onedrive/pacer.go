@@ -0,0 +1,77 @@
+package onedrive
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Pacer は rclone の onedrive バックエンドにあるものと同じ考え方で、
+// リクエストの最小間隔を保ちつつ 429/503 を受けるたびに間隔を広げ、
+// 成功するたびに元に戻していく適応的なレートリミッターを実装する。
+type Pacer struct {
+	minSleep time.Duration
+	maxSleep time.Duration
+
+	mu        sync.Mutex
+	sleepTime time.Duration
+}
+
+// retryBackoffFloor は recordRetryable が Retry-After なしで倍々に増やして
+// いくときの最小値。minSleep が 0 (間隔を置かない設定) でもここから倍増
+// できるようにする。
+const retryBackoffFloor = 100 * time.Millisecond
+
+// NewPacer はリクエスト間の最小間隔 minSleep から始まる Pacer を生成する。
+func NewPacer(minSleep time.Duration) *Pacer {
+	return &Pacer{
+		minSleep:  minSleep,
+		maxSleep:  20 * time.Second,
+		sleepTime: minSleep,
+	}
+}
+
+// wait は現在の sleepTime 分だけ待つ。
+func (p *Pacer) wait(ctx context.Context) error {
+	p.mu.Lock()
+	sleep := p.sleepTime
+	p.mu.Unlock()
+
+	if sleep <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(sleep):
+		return nil
+	}
+}
+
+// recordSuccess はリクエストが成功したときに呼び、間隔を minSleep に向けて
+// 半減させていく。
+func (p *Pacer) recordSuccess() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sleepTime /= 2
+	if p.sleepTime < p.minSleep {
+		p.sleepTime = p.minSleep
+	}
+}
+
+// recordRetryable は 429/503 を受けたときに呼ぶ。retryAfter が指定されて
+// いればそれを、なければ現在の間隔を倍にした値を次回の待ち時間にする。
+func (p *Pacer) recordRetryable(retryAfter time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if retryAfter > 0 {
+		p.sleepTime = retryAfter
+	} else if p.sleepTime < retryBackoffFloor {
+		p.sleepTime = retryBackoffFloor
+	} else {
+		p.sleepTime *= 2
+	}
+	if p.sleepTime > p.maxSleep {
+		p.sleepTime = p.maxSleep
+	}
+}
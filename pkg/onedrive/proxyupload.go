@@ -0,0 +1,353 @@
+package onedrive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultProxyChunkSize = 10 * 1024 * 1024 // 10 MiB, aligned to graphChunkUnit
+
+// ProxyUploader は呼び出し元からバイト列をこのモジュール経由で Graph に
+// 流し込む「サーバーサイドアップロード」を行う。ブラウザに uploadUrl を直接
+// 渡す Uploader と異なり、この型はバックエンドが Graph と通信する
+// Cloudreve の "server-side chunked transfer" と同じ構成を取る。
+type ProxyUploader struct {
+	client *Client
+
+	// ChunkSize は 1 PUT あたりのバイト数。graphChunkUnit の倍数であること。
+	ChunkSize int64
+	// Workers はチャンクの下ごしらえ (帯域制限の待ち合わせなど) を行う
+	// ワーカーゴルーチンの数。Graph はセッション内のバイト範囲を順序通りに
+	// しか受け付けないため PUT 自体は index 順の直列実行のままだが、
+	// Workers を増やすと複数チャンクの下ごしらえを前の PUT の完了を待たずに
+	// 並行して進められる。
+	Workers int
+	// MaxAttempts は 1 チャンクあたりの最大試行回数 (初回を含む)。
+	MaxAttempts int
+	// Backoff は再試行までの待ち時間を決める。nil なら defaultBackoff を使う。
+	Backoff BackoffPolicy
+	// BandwidthLimit はセッションごとのアップロード速度の上限 (バイト/秒)。
+	// 0 以下なら無制限。
+	BandwidthLimit int64
+
+	mu        sync.Mutex
+	uploadURL string
+}
+
+// ProxyUploaderOption は ProxyUploader の生成時に設定を上書きする。
+type ProxyUploaderOption func(*ProxyUploader)
+
+// WithProxyChunkSize はチャンクサイズを変更する。
+func WithProxyChunkSize(size int64) ProxyUploaderOption {
+	return func(p *ProxyUploader) { p.ChunkSize = size }
+}
+
+// WithProxyWorkers はチャンクの下ごしらえを並行して行うワーカー数を変更する。
+func WithProxyWorkers(workers int) ProxyUploaderOption {
+	return func(p *ProxyUploader) { p.Workers = workers }
+}
+
+// WithProxyMaxAttempts は 1 チャンクあたりの最大試行回数を変更する。
+func WithProxyMaxAttempts(attempts int) ProxyUploaderOption {
+	return func(p *ProxyUploader) { p.MaxAttempts = attempts }
+}
+
+// WithProxyBackoff は再試行の待ち時間ポリシーを変更する。
+func WithProxyBackoff(b BackoffPolicy) ProxyUploaderOption {
+	return func(p *ProxyUploader) { p.Backoff = b }
+}
+
+// WithBandwidthLimit はセッションの転送速度の上限 (バイト/秒) を設定する。
+func WithBandwidthLimit(bytesPerSec int64) ProxyUploaderOption {
+	return func(p *ProxyUploader) { p.BandwidthLimit = bytesPerSec }
+}
+
+// NewProxyUploader は Client に紐づく ProxyUploader を生成する。
+func NewProxyUploader(c *Client, opts ...ProxyUploaderOption) (*ProxyUploader, error) {
+	p := &ProxyUploader{
+		client:      c,
+		ChunkSize:   defaultProxyChunkSize,
+		Workers:     4,
+		MaxAttempts: 5,
+		Backoff:     defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.ChunkSize%graphChunkUnit != 0 {
+		return nil, fmt.Errorf("chunk size %d must be a multiple of %d bytes", p.ChunkSize, graphChunkUnit)
+	}
+	if p.Workers < 1 {
+		p.Workers = 1
+	}
+	if p.Backoff == nil {
+		p.Backoff = defaultBackoff
+	}
+	return p, nil
+}
+
+type proxyChunk struct {
+	data   []byte
+	offset int64
+	index  int
+	isLast bool
+}
+
+// Upload は r から読み出した内容をアップロードする。size が既知であれば
+// (Content-Length が分かっている HTTP アップロードの中継など) そのまま使い、
+// 0 以下ならいったん一時ファイルにスプールしてサイズを確定させる。
+// r は Seek できる必要はない。
+//
+// チャンクの下ごしらえ (帯域制限の待ち合わせなど) は Workers 個のゴルーチン
+// が並行に行う。読み出し自体は単一の io.Reader からなので直列にしか進めない
+// が、下ごしらえが終わったチャンクは index 順に並べ直してから PUT するため、
+// 前のチャンクを PUT している間に後続チャンクの下ごしらえを進められる。
+// ただし Graph 側がセッション内のバイト範囲を順序通りにしか受け付けないため、
+// PUT 自体は index 順の直列実行のままになる。
+func (p *ProxyUploader) Upload(ctx context.Context, r io.Reader, size int64, destPath string) (*DriveItem, error) {
+	if size <= 0 {
+		spooled, spooledSize, cleanup, err := spoolToTempFile(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to spool upload of unknown size: %w", err)
+		}
+		defer cleanup()
+		r = spooled
+		size = spooledSize
+	}
+
+	uploadURL, err := p.client.createUploadSession(ctx, destPath)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.uploadURL = uploadURL
+	p.mu.Unlock()
+
+	numChunks := int((size + p.ChunkSize - 1) / p.ChunkSize)
+	if numChunks == 0 {
+		numChunks = 1
+	}
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var abortOnce sync.Once
+	abortCh := make(chan struct{})
+	var abortErr error
+	abort := func(err error) {
+		abortOnce.Do(func() {
+			abortErr = err
+			cancel()
+			close(abortCh)
+		})
+	}
+
+	raw := make(chan proxyChunk, p.Workers)
+	go p.readChunks(workCtx, r, size, raw, abort)
+
+	prepared := make([]chan proxyChunk, numChunks)
+	for i := range prepared {
+		prepared[i] = make(chan proxyChunk, 1)
+	}
+
+	bucket := newTokenBucket(p.BandwidthLimit)
+	var wg sync.WaitGroup
+	for w := 0; w < p.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range raw {
+				if err := bucket.Wait(workCtx, int64(len(chunk.data))); err != nil {
+					abort(err)
+					return
+				}
+				prepared[chunk.index] <- chunk
+			}
+		}()
+	}
+
+	var result *DriveItem
+	for i := 0; i < numChunks; i++ {
+		select {
+		case chunk := <-prepared[i]:
+			item, isLast, err := p.putChunk(ctx, uploadURL, chunk.data, chunk.offset, size)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			p.client.recordBytesUploaded(int64(len(chunk.data)))
+			if isLast {
+				result = item
+			}
+		case <-abortCh:
+			return nil, fmt.Errorf("failed to prepare upload chunk: %w", abortErr)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	wg.Wait()
+	if result == nil {
+		return nil, fmt.Errorf("upload session ended before the final chunk was acknowledged")
+	}
+	return result, nil
+}
+
+// readChunks は src を ChunkSize ごとに区切って raw に流し込む。io.Reader から
+// の読み出しは単一のゴルーチンでしか行えないため直列になるが、各チャンクには
+// index を付けておき、後段の Workers 個のワーカーが並行して下ごしらえをした
+// 後でも元の順序に並べ直せるようにする。途中で読み出しが失敗した場合は abort
+// を呼んで workCtx を倒す。そうしないと、失敗した index を永遠に待ち続ける
+// Upload 側の prepared[i] 受信が abortCh にも ctx.Done() にも引っかからず
+// デッドロックしてしまう。
+func (p *ProxyUploader) readChunks(ctx context.Context, src io.Reader, total int64, raw chan<- proxyChunk, abort func(error)) {
+	defer close(raw)
+
+	offset := int64(0)
+	for index := 0; offset < total; index++ {
+		size := p.ChunkSize
+		if remaining := total - offset; size > remaining {
+			size = remaining
+		}
+
+		buf := make([]byte, size)
+		n, err := io.ReadFull(src, buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			abort(err)
+			return
+		}
+
+		chunk := proxyChunk{
+			data:   buf[:n],
+			offset: offset,
+			index:  index,
+			isLast: offset+int64(n) >= total,
+		}
+		offset += int64(n)
+
+		select {
+		case raw <- chunk:
+		case <-ctx.Done():
+			abort(ctx.Err())
+			return
+		}
+	}
+}
+
+// putChunk はチャンクを PUT し、5xx/429 を再試行可能なエラーとして扱う。
+// バイト列はすでにメモリ上にあるため、Uploader と違いファイルの再シークは
+// 不要で、同じスライスを投げ直すだけでよい。
+func (p *ProxyUploader) putChunk(ctx context.Context, uploadURL string, data []byte, offset, total int64) (*DriveItem, bool, error) {
+	var lastErr error
+
+	for attempt := 1; attempt <= p.MaxAttempts; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(data))
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to create chunk upload request: %w", err)
+		}
+		req.Header.Set("Content-Length", strconv.Itoa(len(data)))
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(len(data))-1, total))
+
+		resp, err := p.client.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to send chunk upload request: %w", err)
+			p.sleepBeforeRetry(ctx, attempt, 0)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			retryAfter := retryAfterDuration(resp.Header.Get("Retry-After"))
+			resp.Body.Close()
+			lastErr = fmt.Errorf("chunk upload failed: %s", resp.Status)
+			p.sleepBeforeRetry(ctx, attempt, retryAfter)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusAccepted {
+			resp.Body.Close()
+			return nil, false, nil
+		}
+
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+			defer resp.Body.Close()
+			var item DriveItem
+			if err := decodeJSON(resp.Body, &item); err != nil {
+				return nil, false, fmt.Errorf("failed to decode drive item: %w", err)
+			}
+			return &item, true, nil
+		}
+
+		resp.Body.Close()
+		return nil, false, fmt.Errorf("chunk upload failed: %s", resp.Status)
+	}
+
+	return nil, false, fmt.Errorf("chunk upload failed after %d attempts: %w", p.MaxAttempts, lastErr)
+}
+
+func (p *ProxyUploader) sleepBeforeRetry(ctx context.Context, attempt int, retryAfter time.Duration) {
+	wait := retryAfter
+	if wait == 0 {
+		wait = p.Backoff.Next(attempt)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(wait):
+	}
+}
+
+// Cancel はアップロードセッションを解放するため DELETE {uploadUrl} を発行する。
+func (p *ProxyUploader) Cancel(ctx context.Context) error {
+	p.mu.Lock()
+	uploadURL := p.uploadURL
+	p.mu.Unlock()
+	if uploadURL == "" {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, uploadURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create cancel request: %w", err)
+	}
+
+	resp, err := p.client.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to cancel upload session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to cancel upload session: %s", resp.Status)
+	}
+	return nil
+}
+
+// spoolToTempFile はサイズ不明の Reader を一時ファイルへ書き出し、読み出し用に
+// 先頭へ巻き戻して返す。呼び出し元は cleanup でファイルの削除まで行うこと。
+func spoolToTempFile(r io.Reader) (*os.File, int64, func(), error) {
+	tmp, err := os.CreateTemp("", "onedrive-proxy-upload-*")
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("failed to spool content: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("failed to rewind spooled content: %w", err)
+	}
+	return tmp, size, cleanup, nil
+}
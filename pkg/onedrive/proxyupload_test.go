@@ -0,0 +1,134 @@
+package onedrive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeProxyTokenSource struct{}
+
+func (fakeProxyTokenSource) Token(ctx context.Context) (Token, error) {
+	return Token{AccessToken: "fake-token", ExpiresIn: 3600}, nil
+}
+
+func newProxyUploadTestClient(t *testing.T, srv *httptest.Server) *Client {
+	t.Helper()
+	return NewUserDriveClient(fakeProxyTokenSource{},
+		WithEndpoints(Endpoints{GraphURL: srv.URL}),
+		WithHTTPClient(srv.Client()),
+	)
+}
+
+func TestProxyUploaderUploadEndToEnd(t *testing.T) {
+	var received bytes.Buffer
+	var uploadURL string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1.0/me/drive/root:/dest.txt:/createUploadSession", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"uploadUrl": uploadURL})
+	})
+	mux.HandleFunc("/upload-session", func(w http.ResponseWriter, r *http.Request) {
+		var start, end, total int64
+		if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total); err != nil {
+			http.Error(w, "bad Content-Range", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "read failed", http.StatusBadRequest)
+			return
+		}
+		received.Write(body)
+
+		if end+1 < total {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"id": "item-1"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	uploadURL = srv.URL + "/upload-session"
+
+	client := newProxyUploadTestClient(t, srv)
+	p, err := NewProxyUploader(client, WithProxyChunkSize(graphChunkUnit), WithProxyWorkers(2))
+	if err != nil {
+		t.Fatalf("NewProxyUploader returned error: %v", err)
+	}
+
+	content := bytes.Repeat([]byte("x"), 3*graphChunkUnit)
+	item, err := p.Upload(context.Background(), bytes.NewReader(content), int64(len(content)), "dest.txt")
+	if err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+	if item.ID != "item-1" {
+		t.Errorf("item.ID = %q, want item-1", item.ID)
+	}
+	if !bytes.Equal(received.Bytes(), content) {
+		t.Errorf("server received %d bytes, want %d matching bytes", received.Len(), len(content))
+	}
+}
+
+func TestProxyUploaderUploadAbortsOnReaderErrorInsteadOfHanging(t *testing.T) {
+	var uploadURL string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1.0/me/drive/root:/dest.txt:/createUploadSession", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"uploadUrl": uploadURL})
+	})
+	mux.HandleFunc("/upload-session", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	uploadURL = srv.URL + "/upload-session"
+
+	client := newProxyUploadTestClient(t, srv)
+	p, err := NewProxyUploader(client, WithProxyChunkSize(graphChunkUnit), WithProxyWorkers(2))
+	if err != nil {
+		t.Fatalf("NewProxyUploader returned error: %v", err)
+	}
+
+	// 3 チャンク分のサイズを申告するが、読み出し元は 1 チャンク分しか持っておらず
+	// その後エラーを返す。readChunks がこのエラーを abort() 経由で伝えないと、
+	// Upload は生成されなかった 2, 3 番目のチャンクを待ったまま永遠にブロックする。
+	readErr := errors.New("boom: upstream connection dropped")
+	src := io.MultiReader(
+		strings.NewReader(strings.Repeat("x", graphChunkUnit)),
+		errReader{readErr},
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Upload(context.Background(), src, int64(3*graphChunkUnit), "dest.txt")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Upload succeeded, want an error from the failing reader")
+		}
+		if !errors.Is(err, readErr) {
+			t.Errorf("Upload error = %v, want it to wrap %v", err, readErr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Upload did not return within 5s; it deadlocked waiting on a chunk the reader never produced")
+	}
+}
+
+type errReader struct{ err error }
+
+func (e errReader) Read([]byte) (int, error) { return 0, e.err }
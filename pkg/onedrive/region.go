@@ -0,0 +1,79 @@
+package onedrive
+
+import "net/url"
+
+// Region は接続先となる Microsoft クラウドの環境を表す。
+type Region string
+
+const (
+	// RegionGlobal は通常の Microsoft 365 (商用グローバル環境) を表す。
+	RegionGlobal Region = "global"
+	// RegionChina は世紀互聯 (21Vianet) が運用する中国向け環境を表す。
+	RegionChina Region = "cn"
+	// RegionUSGov は米国政府機関向け (GCC High / DoD) 環境を表す。
+	RegionUSGov Region = "us"
+	// RegionGermany はドイツのデータレジデンシー要件向け環境を表す。
+	RegionGermany Region = "de"
+)
+
+// Endpoints は認証エンドポイントと Graph API のベース URL の組を表す。
+// Region ごとに異なるホスト名を吸収するために使う。
+type Endpoints struct {
+	AuthURL  string
+	GraphURL string
+}
+
+// endpointsByRegion は各 Region に対応する Endpoints を保持する。
+var endpointsByRegion = map[Region]Endpoints{
+	RegionGlobal: {
+		AuthURL:  "https://login.microsoftonline.com",
+		GraphURL: "https://graph.microsoft.com",
+	},
+	RegionChina: {
+		AuthURL:  "https://login.partner.microsoftonline.cn",
+		GraphURL: "https://microsoftgraph.chinacloudapi.cn",
+	},
+	RegionUSGov: {
+		AuthURL:  "https://login.microsoftonline.us",
+		GraphURL: "https://graph.microsoft.us",
+	},
+	RegionGermany: {
+		AuthURL:  "https://login.microsoftonline.de",
+		GraphURL: "https://graph.microsoft.de",
+	},
+}
+
+// endpointsFor は Region に対応する Endpoints を返す。未知の Region は
+// RegionGlobal にフォールバックする。
+func endpointsFor(region Region) Endpoints {
+	if ep, ok := endpointsByRegion[region]; ok {
+		return ep
+	}
+	return endpointsByRegion[RegionGlobal]
+}
+
+// EndpointsForRegion は Region に対応する Endpoints を返す。TokenSource の
+// 実装を自分で組み立てる呼び出し元向けに endpointsFor を公開したもの。
+func EndpointsForRegion(region Region) Endpoints {
+	return endpointsFor(region)
+}
+
+// knownGraphHosts は 4 つの Region すべての Graph API ホスト名を保持する。
+// グローバル環境の "graph.microsoft.com" 以外は似た命名規則ですらないため
+// (例: 中国環境の "microsoftgraph.chinacloudapi.cn")、部分文字列一致では
+// 他の Region を取りこぼす。
+var knownGraphHosts = func() map[string]bool {
+	hosts := make(map[string]bool, len(endpointsByRegion))
+	for _, ep := range endpointsByRegion {
+		if u, err := url.Parse(ep.GraphURL); err == nil {
+			hosts[u.Host] = true
+		}
+	}
+	return hosts
+}()
+
+// isKnownGraphHost は host がいずれかの Region の Graph API ホストと一致するか
+// を返す。
+func isKnownGraphHost(host string) bool {
+	return knownGraphHosts[host]
+}
@@ -0,0 +1,310 @@
+// Package subscriptions は Microsoft Graph の変更通知 (change notification)
+// サブスクリプションを管理する。ドライブアイテムへの変更を Webhook で受け取り、
+// 期限切れ前の自動更新、clientState によるコールバックの検証、delta token を
+// 使った増分同期までをまとめて扱う。
+package subscriptions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tsubasa-2005/OneDriveAPI/pkg/onedrive"
+)
+
+// maxTTL はドライブリソースに対する Graph サブスクリプションの最大有効期間。
+// Graph のドキュメント上、drive/driveItem はおよそ 3 日が上限。
+const maxTTL = 3 * 24 * time.Hour
+
+// renewMargin は期限切れの何分前に自動更新するか。
+const renewMargin = 10 * time.Minute
+
+// Subscription は作成済みの Graph サブスクリプションを表す。
+type Subscription struct {
+	ID           string
+	Resource     string
+	ClientState  string
+	ExpiresAt    time.Time
+	notifyStopCh chan struct{}
+}
+
+// ChangeNotification は Webhook コールバックで受け取った 1 件の変更通知。
+type ChangeNotification struct {
+	SubscriptionID string
+	ClientState    string
+	ChangeType     string
+	Resource       string
+}
+
+// Manager はサブスクリプションの作成・自動更新・コールバック検証をまとめて
+// 扱う。NotificationURL は Subscribe で使う notificationUrl で、このプロセスが
+// 到達可能な Webhook のエンドポイントを指定する。
+type Manager struct {
+	client          *onedrive.Client
+	NotificationURL string
+
+	mu   sync.Mutex
+	subs map[string]*Subscription
+
+	notifications chan ChangeNotification
+}
+
+// NewManager は Manager を生成する。notificationURL は Handler を公開している
+// エンドポイントの URL。
+func NewManager(client *onedrive.Client, notificationURL string) *Manager {
+	return &Manager{
+		client:          client,
+		NotificationURL: notificationURL,
+		subs:            make(map[string]*Subscription),
+		notifications:   make(chan ChangeNotification, 32),
+	}
+}
+
+// Notifications は検証済みの変更通知を受け取るチャンネルを返す。
+func (m *Manager) Notifications() <-chan ChangeNotification {
+	return m.notifications
+}
+
+// Subscribe は resource (例: "/sites/{id}/drive/root") への変更通知サブスクリ
+// プションを作成し、ttl (上限は maxTTL) が切れる前にバックグラウンドで自動更新
+// し続ける。
+func (m *Manager) Subscribe(ctx context.Context, resource string, ttl time.Duration) (*Subscription, error) {
+	if ttl <= 0 || ttl > maxTTL {
+		ttl = maxTTL
+	}
+
+	clientState, err := randomClientState()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate client state: %w", err)
+	}
+
+	sub, err := m.createSubscription(ctx, resource, ttl, clientState)
+	if err != nil {
+		return nil, err
+	}
+	sub.notifyStopCh = make(chan struct{})
+
+	m.mu.Lock()
+	m.subs[sub.ID] = sub
+	m.mu.Unlock()
+
+	go m.renewLoop(ctx, sub, ttl)
+
+	return sub, nil
+}
+
+// Unsubscribe はサブスクリプションを削除し、自動更新ループを止める。
+func (m *Manager) Unsubscribe(ctx context.Context, id string) error {
+	m.mu.Lock()
+	sub, ok := m.subs[id]
+	delete(m.subs, id)
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	close(sub.notifyStopCh)
+
+	req, err := m.client.NewGraphRequest(ctx, http.MethodDelete, "/subscriptions/"+id, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.client.HTTPClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to delete subscription: %s", resp.Status)
+	}
+	return nil
+}
+
+func (m *Manager) createSubscription(ctx context.Context, resource string, ttl time.Duration, clientState string) (*Subscription, error) {
+	expiration := time.Now().Add(ttl)
+	body := map[string]interface{}{
+		"changeType":         "updated",
+		"notificationUrl":    m.NotificationURL,
+		"resource":           resource,
+		"expirationDateTime": expiration.UTC().Format(time.RFC3339),
+		"clientState":        clientState,
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode subscription request: %w", err)
+	}
+
+	req, err := m.client.NewGraphRequest(ctx, http.MethodPost, "/subscriptions", strings.NewReader(string(payload)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.HTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("failed to create subscription: %s", resp.Status)
+	}
+
+	var created struct {
+		ID                 string `json:"id"`
+		ExpirationDateTime string `json:"expirationDateTime"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("failed to decode subscription response: %w", err)
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, created.ExpirationDateTime)
+	if err != nil {
+		expiresAt = expiration
+	}
+
+	return &Subscription{
+		ID:          created.ID,
+		Resource:    resource,
+		ClientState: clientState,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// renewLoop は期限切れの renewMargin 前になるたびサブスクリプションを更新する。
+func (m *Manager) renewLoop(ctx context.Context, sub *Subscription, ttl time.Duration) {
+	for {
+		wait := time.Until(sub.ExpiresAt.Add(-renewMargin))
+		if wait < 0 {
+			wait = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.notifyStopCh:
+			return
+		case <-time.After(wait):
+		}
+
+		newExpiry, err := m.renew(ctx, sub.ID, ttl)
+		if err != nil {
+			// 更新に失敗した場合は短い間隔で再試行する。
+			select {
+			case <-ctx.Done():
+				return
+			case <-sub.notifyStopCh:
+				return
+			case <-time.After(time.Minute):
+			}
+			continue
+		}
+
+		m.mu.Lock()
+		sub.ExpiresAt = newExpiry
+		m.mu.Unlock()
+	}
+}
+
+func (m *Manager) renew(ctx context.Context, id string, ttl time.Duration) (time.Time, error) {
+	expiration := time.Now().Add(ttl)
+	body := map[string]string{
+		"expirationDateTime": expiration.UTC().Format(time.RFC3339),
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to encode renewal request: %w", err)
+	}
+
+	req, err := m.client.NewGraphRequest(ctx, http.MethodPatch, "/subscriptions/"+id, strings.NewReader(string(payload)))
+	if err != nil {
+		return time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.HTTPClient().Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to renew subscription: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("failed to renew subscription: %s", resp.Status)
+	}
+	return expiration, nil
+}
+
+// Handler は Graph からのバリデーションチャレンジと変更通知コールバックを
+// 処理する http.Handler を返す。サブスクリプション作成時の clientState と
+// 一致しない通知は 401 で拒否する。
+func (m *Manager) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("validationToken"); token != "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(token))
+			return
+		}
+
+		var payload struct {
+			Value []struct {
+				SubscriptionID string `json:"subscriptionId"`
+				ClientState    string `json:"clientState"`
+				ChangeType     string `json:"changeType"`
+				Resource       string `json:"resource"`
+			} `json:"value"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, "invalid notification payload", http.StatusBadRequest)
+			return
+		}
+
+		m.mu.Lock()
+		for _, n := range payload.Value {
+			sub, ok := m.subs[n.SubscriptionID]
+			if !ok || sub.ClientState != n.ClientState {
+				m.mu.Unlock()
+				http.Error(w, "clientState mismatch", http.StatusUnauthorized)
+				return
+			}
+		}
+		m.mu.Unlock()
+
+		for _, n := range payload.Value {
+			notification := ChangeNotification{
+				SubscriptionID: n.SubscriptionID,
+				ClientState:    n.ClientState,
+				ChangeType:     n.ChangeType,
+				Resource:       n.Resource,
+			}
+			select {
+			case m.notifications <- notification:
+			default:
+				// バッファが溢れている場合は Delta での追いつきに任せ、
+				// コールバック自体はタイムアウトさせずに Accepted を返す。
+			}
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// Delta は Manager に渡された Client に対する増分同期を行う。token には
+// 前回の呼び出しで得たトークンを渡す。
+func (m *Manager) Delta(ctx context.Context, token string) ([]onedrive.DriveItem, string, error) {
+	return m.client.Delta(ctx, token)
+}
+
+func randomClientState() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,89 @@
+package subscriptions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerEchoesValidationToken(t *testing.T) {
+	m := NewManager(nil, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/?validationToken=abc123", nil)
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Body.String(); got != "abc123" {
+		t.Errorf("body = %q, want %q", got, "abc123")
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+}
+
+func TestHandlerRejectsClientStateMismatch(t *testing.T) {
+	m := NewManager(nil, "")
+	m.subs["sub-1"] = &Subscription{ID: "sub-1", ClientState: "expected-state"}
+
+	body := `{"value":[{"subscriptionId":"sub-1","clientState":"wrong-state","changeType":"updated"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandlerRejectsUnknownSubscription(t *testing.T) {
+	m := NewManager(nil, "")
+
+	body := `{"value":[{"subscriptionId":"does-not-exist","clientState":"whatever","changeType":"updated"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandlerAcceptsMatchingClientStateAndDeliversNotification(t *testing.T) {
+	m := NewManager(nil, "")
+	m.subs["sub-1"] = &Subscription{ID: "sub-1", ClientState: "expected-state", Resource: "/me/drive/root"}
+
+	body := `{"value":[{"subscriptionId":"sub-1","clientState":"expected-state","changeType":"updated","resource":"/me/drive/root"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+
+	select {
+	case n := <-m.Notifications():
+		if n.SubscriptionID != "sub-1" || n.ChangeType != "updated" {
+			t.Errorf("notification = %+v, want subscriptionId=sub-1 changeType=updated", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no notification delivered")
+	}
+}
+
+func TestHandlerRejectsInvalidPayload(t *testing.T) {
+	m := NewManager(nil, "")
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not json"))
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,72 @@
+package onedrive
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket はバイト/秒単位の帯域制限を行うシンプルなトークンバケット。
+// ratePerSec が 0 以下の場合は無制限 (Wait は即座に返る)。
+type tokenBucket struct {
+	ratePerSec int64
+	capacity   int64
+
+	mu       sync.Mutex
+	tokens   int64
+	lastFill time.Time
+}
+
+func newTokenBucket(ratePerSec int64) *tokenBucket {
+	return &tokenBucket{
+		ratePerSec: ratePerSec,
+		capacity:   ratePerSec,
+		tokens:     ratePerSec,
+		lastFill:   time.Now(),
+	}
+}
+
+// Wait は n バイト分のトークンが貯まるまでブロックする。n が capacity
+// (= ratePerSec) を超える場合でも一度に使い切る必要はなく、貯まった分から
+// 随時差し引いて残りを待つ。capacity 分を一括で要求すると n がそれを超えた
+// 時点でトークンが絶対に追いつかず無限に待ち続けてしまうため。
+func (b *tokenBucket) Wait(ctx context.Context, n int64) error {
+	if b == nil || b.ratePerSec <= 0 {
+		return nil
+	}
+
+	for n > 0 {
+		b.mu.Lock()
+		now := time.Now()
+		elapsed := now.Sub(b.lastFill).Seconds()
+		b.tokens += int64(elapsed * float64(b.ratePerSec))
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.lastFill = now
+
+		take := n
+		if take > b.tokens {
+			take = b.tokens
+		}
+		b.tokens -= take
+		n -= take
+		b.mu.Unlock()
+
+		if n == 0 {
+			return nil
+		}
+
+		missing := n
+		if missing > b.capacity {
+			missing = b.capacity
+		}
+		wait := time.Duration(float64(missing)/float64(b.ratePerSec)*float64(time.Second)) + time.Millisecond
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package onedrive
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketUnlimitedWhenRateIsZero(t *testing.T) {
+	b := newTokenBucket(0)
+	start := time.Now()
+	if err := b.Wait(context.Background(), 1<<30); err != nil {
+		t.Fatalf("Wait returned error for unlimited bucket: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Wait on unlimited bucket took %v, want effectively instant", elapsed)
+	}
+}
+
+func TestTokenBucketNilIsUnlimited(t *testing.T) {
+	var b *tokenBucket
+	if err := b.Wait(context.Background(), 1<<30); err != nil {
+		t.Fatalf("Wait on nil bucket returned error: %v", err)
+	}
+}
+
+func TestTokenBucketWaitConsumesFromInitialCapacity(t *testing.T) {
+	b := newTokenBucket(1000)
+	if err := b.Wait(context.Background(), 500); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if b.tokens != 500 {
+		t.Errorf("tokens after Wait(500) = %d, want 500", b.tokens)
+	}
+}
+
+func TestTokenBucketWaitBlocksUntilCtxCancelWhenStarved(t *testing.T) {
+	b := newTokenBucket(10)
+	b.mu.Lock()
+	b.tokens = 0
+	b.mu.Unlock()
+
+	// 5 バイト待つには 500ms 必要だが、ctx は 10ms で切れるので
+	// Wait はトークンが貯まる前に ctx.Err() を返すはず。
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := b.Wait(ctx, 5)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Wait error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("Wait took %v to report cancellation, want close to the 10ms deadline", elapsed)
+	}
+}
+
+func TestTokenBucketRefillsOverTime(t *testing.T) {
+	b := newTokenBucket(1000)
+	b.mu.Lock()
+	b.tokens = 0
+	b.lastFill = time.Now().Add(-100 * time.Millisecond)
+	b.mu.Unlock()
+
+	// 1000 バイト/秒で 100ms 経過しているので、約 100 バイト分は待たずに消費できる。
+	if err := b.Wait(context.Background(), 50); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+}
+
+func TestTokenBucketWaitHandlesRequestLargerThanCapacity(t *testing.T) {
+	// capacity (= ratePerSec) は 1000 だが、10 MiB チャンクのような
+	// capacity を大幅に超える n を一度に要求しても、満額が貯まるのを
+	// 待つのではなく随時使い切りながら進んで完了しなければならない。
+	b := newTokenBucket(1000)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := b.Wait(ctx, 3500); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	// 初期トークンで 1000、残り 2500 は 1000 バイト/秒でおよそ 2.5 秒かかる。
+	if elapsed < 2*time.Second || elapsed > 4*time.Second {
+		t.Errorf("Wait(3500) on a 1000/s bucket took %v, want roughly 2.5s", elapsed)
+	}
+}
+
+func TestTokenBucketCapsTokensAtCapacity(t *testing.T) {
+	b := newTokenBucket(100)
+	b.mu.Lock()
+	b.lastFill = time.Now().Add(-time.Hour)
+	b.mu.Unlock()
+
+	if err := b.Wait(context.Background(), 100); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	b.mu.Lock()
+	tokens := b.tokens
+	b.mu.Unlock()
+	if tokens != 0 {
+		t.Errorf("tokens after draining a capacity-capped bucket = %d, want 0", tokens)
+	}
+}
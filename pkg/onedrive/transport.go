@@ -0,0 +1,186 @@
+package onedrive
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxTransportAttempts は Transport が 429/503 を自前で再試行する最大回数。
+const maxTransportAttempts = 5
+
+// Transport は bare な &http.Client{} の代わりに使う http.RoundTripper。
+// リクエスト間隔を調整する Pacer、構造化ログ、Prometheus メトリクス、
+// リバースプロキシ越しへの書き換えをまとめて行う。
+type Transport struct {
+	// Base は実際の通信を行う下位の RoundTripper。nil なら http.DefaultTransport。
+	Base http.RoundTripper
+	// Pacer が設定されていればリクエスト間隔の調整と 429/503 の再試行を行う。
+	Pacer *Pacer
+	// Logger が設定されていればリクエストごとに debug レベルでログを出す。
+	Logger *slog.Logger
+	// Metrics が設定されていれば Prometheus の指標を記録する。
+	Metrics *Metrics
+	// ProxyBaseURL が設定されていると、Graph 宛てのリクエスト (アップロード
+	// セッション URL を含む) のスキーム・ホストをこの URL に書き換える。
+	// CDN やリバースプロキシ経由で OneDrive トラフィックを流したいテナント
+	// 向けのオプション。
+	ProxyBaseURL string
+}
+
+// TransportOption は Transport の生成時に設定を適用する。
+type TransportOption func(*Transport)
+
+// WithPacer は Pacer を設定する。
+func WithPacer(p *Pacer) TransportOption {
+	return func(t *Transport) { t.Pacer = p }
+}
+
+// WithLogger は構造化ログの出力先を設定する。
+func WithLogger(logger *slog.Logger) TransportOption {
+	return func(t *Transport) { t.Logger = logger }
+}
+
+// WithMetrics は Prometheus メトリクスを設定する。
+func WithMetrics(m *Metrics) TransportOption {
+	return func(t *Transport) { t.Metrics = m }
+}
+
+// WithProxyBaseURL はリバースプロキシ越しにリクエストを流すための置き換え先
+// を設定する。
+func WithProxyBaseURL(proxyBaseURL string) TransportOption {
+	return func(t *Transport) { t.ProxyBaseURL = proxyBaseURL }
+}
+
+// WithBaseTransport は下位の http.RoundTripper を差し替える。
+func WithBaseTransport(base http.RoundTripper) TransportOption {
+	return func(t *Transport) { t.Base = base }
+}
+
+// NewTransport は Transport を生成する。
+func NewTransport(opts ...TransportOption) *Transport {
+	t := &Transport{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// NewHTTPClient は Transport を組み込んだ *http.Client を生成する。
+// WithHTTPClient と組み合わせて Client に渡す。
+func NewHTTPClient(opts ...TransportOption) *http.Client {
+	return &http.Client{Transport: NewTransport(opts...)}
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip はリクエストごとに Pacer の待機、プロキシ書き換え、ログ出力、
+// メトリクス記録を行い、429/503 は Retry-After を尊重しつつ再試行する。
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = t.rewriteForProxy(req)
+	requestID := randomRequestID()
+	req.Header.Set("X-Request-Id", requestID)
+
+	op := classifyOp(req.Method, req.URL.Path)
+
+	var resp *http.Response
+	var err error
+
+	for attempt := 1; attempt <= maxTransportAttempts; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			clone := req.Clone(req.Context())
+			clone.Body = body
+			attemptReq = clone
+		}
+
+		if t.Pacer != nil {
+			if waitErr := t.Pacer.wait(req.Context()); waitErr != nil {
+				return nil, waitErr
+			}
+		}
+
+		start := time.Now()
+		resp, err = t.base().RoundTrip(attemptReq)
+		duration := time.Since(start)
+
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+
+		if t.Logger != nil {
+			t.Logger.Debug("onedrive request",
+				"method", req.Method,
+				"url", req.URL.String(),
+				"status", status,
+				"duration", duration,
+				"request_id", requestID,
+				"attempt", attempt,
+			)
+		}
+		if t.Metrics != nil {
+			t.Metrics.RequestsTotal.WithLabelValues(op, strconv.Itoa(status)).Inc()
+			t.Metrics.RequestDuration.WithLabelValues(op).Observe(duration.Seconds())
+		}
+
+		if err != nil {
+			return resp, err
+		}
+
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+		if retryable && t.Pacer != nil && attempt < maxTransportAttempts {
+			retryAfter := retryAfterDuration(resp.Header.Get("Retry-After"))
+			resp.Body.Close()
+			t.Pacer.recordRetryable(retryAfter)
+			continue
+		}
+
+		if t.Pacer != nil {
+			t.Pacer.recordSuccess()
+		}
+		return resp, nil
+	}
+
+	return resp, err
+}
+
+// rewriteForProxy は ProxyBaseURL が設定されている場合に、Graph 宛ての
+// リクエストのスキームとホストをプロキシのものへ置き換える。グローバル以外
+// の Region (中国・米国政府・ドイツ) のホストも isKnownGraphHost で拾う。
+func (t *Transport) rewriteForProxy(req *http.Request) *http.Request {
+	if t.ProxyBaseURL == "" || !isKnownGraphHost(req.URL.Host) {
+		return req
+	}
+
+	proxyURL, err := req.URL.Parse(t.ProxyBaseURL)
+	if err != nil {
+		return req
+	}
+
+	clone := req.Clone(req.Context())
+	clone.URL.Scheme = proxyURL.Scheme
+	clone.URL.Host = proxyURL.Host
+	clone.Host = proxyURL.Host
+	return clone
+}
+
+func randomRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,425 @@
+package onedrive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// graphChunkUnit は Graph が要求するアップロードチャンクサイズの単位。
+// ChunkSize はこの倍数でなければならない。
+const graphChunkUnit = 320 * 1024
+
+// smallFileThreshold 未満のファイルはアップロードセッションを使わず、単一の
+// PUT /content で済ませる。
+const smallFileThreshold = 4 * 1024 * 1024
+
+// DriveItem は Graph のドライブアイテムのメタデータを表す。アップロードや
+// CRUD 系のメソッドが返す際は、呼び出しに応じて一部のフィールドのみが
+// 埋まることがある。
+type DriveItem struct {
+	ID              string         `json:"id"`
+	Name            string         `json:"name"`
+	WebURL          string         `json:"webUrl"`
+	Size            int64          `json:"size"`
+	ETag            string         `json:"eTag"`
+	File            *FileFacet     `json:"file,omitempty"`
+	Folder          *FolderFacet   `json:"folder,omitempty"`
+	Thumbnails      []ThumbnailSet `json:"thumbnails,omitempty"`
+	ParentReference *ItemReference `json:"parentReference,omitempty"`
+}
+
+// FileFacet はファイル固有のメタデータ。Hashes には sha1Hash / sha256Hash /
+// quickXorHash が入る (Graph が算出済みのものだけ)。
+type FileFacet struct {
+	MimeType string            `json:"mimeType"`
+	Hashes   map[string]string `json:"hashes"`
+}
+
+// FolderFacet はフォルダー固有のメタデータ。
+type FolderFacet struct {
+	ChildCount int `json:"childCount"`
+}
+
+// ItemReference は親アイテムやサイト/ドライブへの参照を表す。
+type ItemReference struct {
+	DriveID string `json:"driveId"`
+	ID      string `json:"id"`
+	Path    string `json:"path"`
+}
+
+// ThumbnailSet は 1 つのアイテムに対するサムネイルの大小バリエーション。
+type ThumbnailSet struct {
+	Small  *Thumbnail `json:"small,omitempty"`
+	Medium *Thumbnail `json:"medium,omitempty"`
+	Large  *Thumbnail `json:"large,omitempty"`
+}
+
+// Thumbnail は個々のサムネイル画像への参照。
+type Thumbnail struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// ProgressFunc はアップロードの進捗を報告するコールバック。
+// uploaded と total はともにバイト数。
+type ProgressFunc func(uploaded, total int64)
+
+// Uploader はレジューム可能なチャンクアップロードを行う。Client と違い
+// アップロードに固有の設定 (チャンクサイズ・再試行ポリシー・進捗通知) を
+// まとめて保持する。
+type Uploader struct {
+	client *Client
+
+	// ChunkSize は 1 回の PUT で送るバイト数。graphChunkUnit の倍数であること。
+	ChunkSize int64
+	// MaxAttempts は 1 チャンクあたりの最大試行回数 (初回を含む)。
+	MaxAttempts int
+	// Backoff は再試行までの待ち時間を決める。nil なら defaultBackoff を使う。
+	Backoff BackoffPolicy
+	// Progress が設定されていれば、チャンクごとの送信完了時に呼ばれる。
+	Progress ProgressFunc
+}
+
+// UploaderOption は Uploader の生成時に設定を上書きする。
+type UploaderOption func(*Uploader)
+
+// WithChunkSize はチャンクサイズを変更する。graphChunkUnit (320 KiB) の倍数
+// でなければエラーになる。
+func WithChunkSize(size int64) UploaderOption {
+	return func(u *Uploader) {
+		u.ChunkSize = size
+	}
+}
+
+// WithMaxAttempts は 1 チャンクあたりの最大試行回数を変更する。
+func WithMaxAttempts(attempts int) UploaderOption {
+	return func(u *Uploader) {
+		u.MaxAttempts = attempts
+	}
+}
+
+// WithBackoff は再試行の待ち時間ポリシーを変更する。
+func WithBackoff(b BackoffPolicy) UploaderOption {
+	return func(u *Uploader) {
+		u.Backoff = b
+	}
+}
+
+// WithProgress は進捗コールバックを設定する。
+func WithProgress(f ProgressFunc) UploaderOption {
+	return func(u *Uploader) {
+		u.Progress = f
+	}
+}
+
+// NewUploader は Client に紐づく Uploader を生成する。
+func NewUploader(c *Client, opts ...UploaderOption) (*Uploader, error) {
+	u := &Uploader{
+		client:      c,
+		ChunkSize:   10 * graphChunkUnit,
+		MaxAttempts: 5,
+		Backoff:     defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	if u.ChunkSize%graphChunkUnit != 0 {
+		return nil, fmt.Errorf("chunk size %d must be a multiple of %d bytes", u.ChunkSize, graphChunkUnit)
+	}
+	if u.Backoff == nil {
+		u.Backoff = defaultBackoff
+	}
+	return u, nil
+}
+
+// Upload はファイル全体をアップロードする。destDir はドライブルートからの
+// 相対ディレクトリ (例: "Documents/reports")。4 MiB 未満のファイルは単一の
+// PUT で、それ以上はアップロードセッションとチャンク PUT で送られる。
+func (u *Uploader) Upload(ctx context.Context, filePath, destDir string) (*DriveItem, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	destPath := destPathFor(destDir, filepath.Base(filePath))
+
+	if info.Size() < smallFileThreshold {
+		return u.uploadSmall(ctx, file, info.Size(), destPath)
+	}
+	return u.uploadSession(ctx, file, info.Size(), destPath, "")
+}
+
+// Resume は既存のアップロードセッション URL から続きをアップロードする。
+// セッションの nextExpectedRanges を問い合わせてから、そのオフセット以降の
+// チャンクだけを送り直す。destPath はセッションがすでに完了していた場合に
+// GetItem で確定済みの DriveItem を取り直すために使うので、元の Upload に
+// 渡したものと同じ宛先パスを渡すこと。
+func (u *Uploader) Resume(ctx context.Context, uploadURL, filePath, destPath string) (*DriveItem, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	return u.uploadSession(ctx, file, info.Size(), destPath, uploadURL)
+}
+
+func destPathFor(destDir, fileName string) string {
+	if destDir == "" {
+		return fileName
+	}
+	return destDir + "/" + fileName
+}
+
+func (u *Uploader) uploadSmall(ctx context.Context, file *os.File, totalSize int64, destPath string) (*DriveItem, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	req, err := u.client.newRequest(ctx, http.MethodPut, fmt.Sprintf("/root:/%s:/content", destPath), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload request: %w", err)
+	}
+
+	resp, err := u.client.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send upload request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("upload failed: %s", resp.Status)
+	}
+
+	var item DriveItem
+	if err := decodeJSON(resp.Body, &item); err != nil {
+		return nil, fmt.Errorf("failed to decode drive item: %w", err)
+	}
+
+	u.client.recordBytesUploaded(totalSize)
+	if u.Progress != nil {
+		u.Progress(totalSize, totalSize)
+	}
+	return &item, nil
+}
+
+// uploadSession は (必要なら新規に) アップロードセッションを作成し、
+// nextExpectedRanges から続きをチャンク単位でアップロードする。
+// resumeURL が空でなければ、destPath を無視してそのセッションを再利用する。
+func (u *Uploader) uploadSession(ctx context.Context, file *os.File, totalSize int64, destPath, resumeURL string) (*DriveItem, error) {
+	uploadURL := resumeURL
+	offset := int64(0)
+
+	if uploadURL == "" {
+		var err error
+		uploadURL, err = u.createSession(ctx, destPath)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		offset, err = u.nextExpectedOffset(ctx, uploadURL, totalSize)
+		if err != nil {
+			return nil, err
+		}
+		if offset >= totalSize {
+			// nextExpectedRanges が空、つまりセッションはもう最後まで受信済み。
+			// チャンクループを回しても何も送るものがなく、下の
+			// 「確認されないまま終わった」エラーは誤報になるため、
+			// 確定済みのアイテムを取り直してそのまま返す。
+			item, err := u.client.GetItem(ctx, destPath, GetItemOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch item for an already-completed upload session: %w", err)
+			}
+			if u.Progress != nil {
+				u.Progress(totalSize, totalSize)
+			}
+			return item, nil
+		}
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to resume offset: %w", err)
+	}
+
+	buffer := make([]byte, u.ChunkSize)
+	for offset < totalSize {
+		n, err := io.ReadFull(file, buffer)
+		if err == io.ErrUnexpectedEOF {
+			err = nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read file chunk: %w", err)
+		}
+		if n == 0 {
+			break
+		}
+
+		item, isLast, err := u.putChunk(ctx, file, uploadURL, buffer[:n], offset, totalSize)
+		if err != nil {
+			return nil, err
+		}
+		offset += int64(n)
+		u.client.recordBytesUploaded(int64(n))
+
+		if u.Progress != nil {
+			u.Progress(offset, totalSize)
+		}
+		if isLast {
+			return item, nil
+		}
+	}
+
+	return nil, fmt.Errorf("upload session ended before the final chunk was acknowledged")
+}
+
+func (u *Uploader) createSession(ctx context.Context, destPath string) (string, error) {
+	return u.client.createUploadSession(ctx, destPath)
+}
+
+// nextExpectedOffset は既存セッションの状態を問い合わせ、次に送るべき
+// バイトオフセットを返す。
+func (u *Uploader) nextExpectedOffset(ctx context.Context, uploadURL string, totalSize int64) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uploadURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create session status request: %w", err)
+	}
+
+	resp, err := u.client.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query upload session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to query upload session: %s", resp.Status)
+	}
+
+	var status struct {
+		NextExpectedRanges []string `json:"nextExpectedRanges"`
+	}
+	if err := decodeJSON(resp.Body, &status); err != nil {
+		return 0, fmt.Errorf("failed to decode upload session status: %w", err)
+	}
+	if len(status.NextExpectedRanges) == 0 {
+		return totalSize, nil
+	}
+
+	start, err := parseRangeStart(status.NextExpectedRanges[0])
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse nextExpectedRanges: %w", err)
+	}
+	return start, nil
+}
+
+func parseRangeStart(r string) (int64, error) {
+	for i := 0; i < len(r); i++ {
+		if r[i] == '-' {
+			return strconv.ParseInt(r[:i], 10, 64)
+		}
+	}
+	return strconv.ParseInt(r, 10, 64)
+}
+
+// putChunk はチャンクを PUT し、5xx/429 を再試行可能なエラーとして扱う。
+// Retry-After が返ってくればそちらを優先し、なければ Backoff ポリシーに従う。
+// 再試行の前にはファイルをチャンク先頭までシークし直す (Cloudreve の
+// chunk/backoff パッケージが c.Start() へ巻き戻すのと同じ考え方)。
+func (u *Uploader) putChunk(ctx context.Context, file *os.File, uploadURL string, chunk []byte, offset, totalSize int64) (*DriveItem, bool, error) {
+	var lastErr error
+
+	for attempt := 1; attempt <= u.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			if _, err := file.Seek(offset, io.SeekStart); err != nil {
+				return nil, false, fmt.Errorf("failed to re-seek before retry: %w", err)
+			}
+			if _, err := io.ReadFull(file, chunk); err != nil && err != io.ErrUnexpectedEOF {
+				return nil, false, fmt.Errorf("failed to re-read chunk before retry: %w", err)
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(chunk))
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to create chunk upload request: %w", err)
+		}
+		req.Header.Set("Content-Length", strconv.Itoa(len(chunk)))
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(len(chunk))-1, totalSize))
+
+		resp, err := u.client.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to send chunk upload request: %w", err)
+			u.sleepBeforeRetry(ctx, attempt, 0)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			retryAfter := retryAfterDuration(resp.Header.Get("Retry-After"))
+			resp.Body.Close()
+			lastErr = fmt.Errorf("chunk upload failed: %s", resp.Status)
+			u.sleepBeforeRetry(ctx, attempt, retryAfter)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusAccepted {
+			resp.Body.Close()
+			return nil, false, nil
+		}
+
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+			defer resp.Body.Close()
+			var item DriveItem
+			if err := decodeJSON(resp.Body, &item); err != nil {
+				return nil, false, fmt.Errorf("failed to decode drive item: %w", err)
+			}
+			return &item, true, nil
+		}
+
+		resp.Body.Close()
+		return nil, false, fmt.Errorf("chunk upload failed: %s", resp.Status)
+	}
+
+	return nil, false, fmt.Errorf("chunk upload failed after %d attempts: %w", u.MaxAttempts, lastErr)
+}
+
+func (u *Uploader) sleepBeforeRetry(ctx context.Context, attempt int, retryAfter time.Duration) {
+	wait := retryAfter
+	if wait == 0 {
+		wait = u.Backoff.Next(attempt)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(wait):
+	}
+}
+
+func retryAfterDuration(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
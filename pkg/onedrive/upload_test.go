@@ -0,0 +1,58 @@
+package onedrive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+type fakeUploadTokenSource struct{}
+
+func (fakeUploadTokenSource) Token(ctx context.Context) (Token, error) {
+	return Token{AccessToken: "fake-token", ExpiresIn: 3600}, nil
+}
+
+func TestUploaderResumeFetchesItemWhenSessionAlreadyComplete(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/upload-session", func(w http.ResponseWriter, r *http.Request) {
+		// nextExpectedRanges が空 = セッションはすでに最後まで受信済み。
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{"nextExpectedRanges": []string{}})
+	})
+	mux.HandleFunc("/v1.0/me/drive/root:/dest.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"id": "item-1", "name": "dest.txt"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewUserDriveClient(fakeUploadTokenSource{},
+		WithEndpoints(Endpoints{GraphURL: srv.URL}),
+		WithHTTPClient(srv.Client()),
+	)
+	u, err := NewUploader(client)
+	if err != nil {
+		t.Fatalf("NewUploader returned error: %v", err)
+	}
+
+	tmp, err := os.CreateTemp("", "resume-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write([]byte("hello")); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	tmp.Close()
+
+	item, err := u.Resume(context.Background(), srv.URL+"/upload-session", tmp.Name(), "dest.txt")
+	if err != nil {
+		t.Fatalf("Resume returned error: %v, want it to fetch the already-completed item", err)
+	}
+	if item.ID != "item-1" {
+		t.Errorf("item.ID = %q, want item-1", item.ID)
+	}
+}